@@ -1,17 +1,38 @@
 package jwks
 
 import (
+	"bytes"
+	"context"
+	"crypto"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// discoveryPath is appended to an issuer URL to locate the OIDC discovery document.
+const discoveryPath = "/.well-known/openid-configuration"
+
+// defaultCacheTTL is used when a JWKS response carries no Cache-Control or
+// Expires header. minCacheTTL is the lower bound applied to any TTL derived
+// from response headers, so a misconfigured endpoint can't force constant
+// refetching.
+const (
+	defaultCacheTTL = 15 * time.Minute
+	minCacheTTL     = 2 * time.Minute
+
+	// defaultMinRefreshInterval is the default floor between on-demand
+	// refetches triggered by an unrecognized key id.
+	defaultMinRefreshInterval = minCacheTTL
+)
+
 var httpClient *http.Client
 
 func init() {
@@ -36,77 +57,287 @@ type JSONWebKey struct {
 	Kty string   `json:"kty"`
 	Kid string   `json:"kid"`
 	Use string   `json:"use"`
+	Alg string   `json:"alg"`
 	N   string   `json:"n"`
 	E   string   `json:"e"`
+	Crv string   `json:"crv"`
+	X   string   `json:"x"`
+	Y   string   `json:"y"`
 	X5c []string `json:"x5c"`
+
+	// rawPEM holds the original PEM block this key was parsed from, when it
+	// came from a raw-PEM endpoint rather than a JWKS. It takes precedence
+	// over X5c-based reconstruction in getPEM.
+	rawPEM []byte
+}
+
+// ClientOptions configures the behavior of a Client created with
+// NewClientWithOptions.
+type ClientOptions struct {
+	// MinRefreshInterval bounds how often an unrecognized key id may trigger an
+	// on-demand refetch of the endpoint, and is the default for
+	// RefreshRateLimit. Defaults to
+	// defaultMinRefreshInterval (2 minutes) if zero.
+	MinRefreshInterval time.Duration
+
+	// RefreshRateLimit bounds how often the background refresh goroutine (see
+	// Context) is allowed to hit the endpoint. Defaults to MinRefreshInterval
+	// if zero.
+	RefreshRateLimit time.Duration
+
+	// HTTPClient is used for all requests made by the Client. Defaults to the
+	// package's shared client if nil.
+	HTTPClient *http.Client
+
+	// Context, if non-nil, starts a background goroutine that refreshes the
+	// cache each time it expires. The goroutine stops when
+	// the context is done.
+	Context context.Context
 }
 
 // NewClient returns a Client which is used to fetch keys from a supplied endpoint.
 // It will attempt to cache the keys returned before returning. If an error
 // occurs, it will return an error (with the instantiated Client).
 func NewClient(endpoint string) (*Client, error) {
+	return NewClientWithOptions(endpoint, ClientOptions{})
+}
+
+// NewClientWithOptions is like NewClient but allows callers to configure
+// refresh behavior, the underlying *http.Client, and an optional background
+// refresh goroutine.
+func NewClientWithOptions(endpoint string, opts ClientOptions) (*Client, error) {
+	return newClient(endpoint, "", opts, false)
+}
+
+// NewPEMClient returns a Client that treats endpoint's response as one or
+// more PEM-encoded certificates or SPKI/PKCS1 public keys, rather than a JSON
+// Web Key Set. This supports issuers (older Auth0 tenants, custom IdPs, AWS
+// ALB's /publickey/<kid> scheme) that publish signing keys as PEM. GetKey and
+// GetKeyAsPEM behave identically regardless of which source format was used.
+func NewPEMClient(endpoint string) (*Client, error) {
+	return newClient(endpoint, "", ClientOptions{}, true)
+}
+
+// Client fetchs and maintains a cache of keys from a public endpoint.
+type Client struct {
+	endpoint   string
+	issuer     string
+	httpClient *http.Client
+	forcePEM   bool
+
+	minRefreshInterval time.Duration
+	refreshRateLimit   time.Duration
+
+	keys cache
+}
+
+// oidcConfiguration is the subset of an OIDC discovery document we care about.
+type oidcConfiguration struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// NewClientFromIssuer returns a Client configured via OIDC discovery. It fetches
+// {issuerURL}/.well-known/openid-configuration, extracts the jwks_uri, and then
+// behaves like NewClient against that URI. This lets callers point the client at
+// an OIDC issuer (Auth0, Google, Okta, Keycloak) without hard-coding the JWKS path.
+func NewClientFromIssuer(issuerURL string) (*Client, error) {
+	return NewClientFromIssuerContext(context.Background(), issuerURL)
+}
+
+// NewClientFromIssuerContext is like NewClientFromIssuer but lets callers control
+// timeouts and cancellation for the discovery request.
+func NewClientFromIssuerContext(ctx context.Context, issuerURL string) (*Client, error) {
+	jwksURI, err := discoverJWKSURI(ctx, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(jwksURI, strings.TrimRight(issuerURL, "/"), ClientOptions{}, false)
+}
+
+// Issuer returns the OIDC issuer URL the Client was constructed from, or an empty
+// string if the Client was created with NewClient directly.
+func (c *Client) Issuer() string {
+	return c.issuer
+}
+
+func discoverJWKSURI(ctx context.Context, issuerURL string) (string, error) {
+	discoveryURL := strings.TrimRight(issuerURL, "/") + discoveryPath
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var cfg oidcConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return "", err
+	}
+
+	if cfg.JWKSURI == "" {
+		return "", errors.New("oidc discovery document missing jwks_uri")
+	}
+
+	return cfg.JWKSURI, nil
+}
+
+func newClient(endpoint, issuer string, opts ClientOptions, forcePEM bool) (*Client, error) {
+	hc := opts.HTTPClient
+	if hc == nil {
+		hc = httpClient
+	}
+
+	minRefreshInterval := opts.MinRefreshInterval
+	if minRefreshInterval <= 0 {
+		minRefreshInterval = defaultMinRefreshInterval
+	}
+
+	refreshRateLimit := opts.RefreshRateLimit
+	if refreshRateLimit <= 0 {
+		refreshRateLimit = minRefreshInterval
+	}
+
 	c := &Client{
-		endpoint: endpoint,
+		endpoint:           endpoint,
+		issuer:             issuer,
+		httpClient:         hc,
+		forcePEM:           forcePEM,
+		minRefreshInterval: minRefreshInterval,
+		refreshRateLimit:   refreshRateLimit,
 		keys: cache{
 			kv:  make(map[string]interface{}),
 			mtx: &sync.RWMutex{},
 		},
 	}
 
-	return c, c.updateCache()
-}
+	if err := c.refresh(true); err != nil {
+		return c, err
+	}
 
-// Client fetchs and maintains a cache of keys from a public endpoint.
-type Client struct {
-	endpoint string
-	keys     cache
+	if opts.Context != nil {
+		go c.backgroundRefresh(opts.Context)
+	}
+
+	return c, nil
 }
 
-// GetKey returns a key for a given key id.
-// It first looks in the Client's cache and if it can not find a key it
-// will attempt fetch the key from the endpoint directly.
-func (c *Client) GetKey(kid string) (interface{}, error) {
-	key, ok := c.keys.get(kid)
-	if !ok {
-		if err := c.updateCache(); err != nil {
-			return nil, err
+// backgroundRefresh refetches the JWKS each time the cache's TTL runs out,
+// waiting at least refreshRateLimit between attempts. Cache lookups do not
+// check the expiry themselves. It exits when ctx is done.
+func (c *Client) backgroundRefresh(ctx context.Context) {
+	for {
+		wait := c.keys.timeUntilExpiry()
+		if wait < c.refreshRateLimit {
+			wait = c.refreshRateLimit
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+			c.refresh(true)
 		}
 	}
+}
 
-	key, ok = c.keys.get(kid)
-	if !ok {
-		return nil, errors.New("unrecognized key id")
+// GetKey returns the crypto.PublicKey for a given key id, derived from the
+// JWK's kty-specific parameters (or, failing that, its x5c certificate). It
+// first looks in the Client's cache and if it can not find a key it will
+// attempt to fetch the key from the endpoint directly.
+func (c *Client) GetKey(kid string) (crypto.PublicKey, error) {
+	jwk, err := c.getRawKey(kid)
+	if err != nil {
+		return nil, err
 	}
 
-	return key, nil
+	return jwk.PublicKey()
 }
 
 func (c *Client) GetKeyAsPEM(kid string) ([]byte, error) {
-	key, err := c.GetKey(kid)
+	jwk, err := c.getRawKey(kid)
 	if err != nil {
 		return nil, err
 	}
-	pem, err := getPEM(key.(JSONWebKey))
+	pem, err := getPEM(jwk)
 	if err != nil {
 		return nil, err
 	}
 	return pem, nil
 }
 
-func (c *Client) updateCache() error {
-	ks, err := fetchJWKs(c.endpoint)
-	if err != nil {
+// getRawKey returns the JSONWebKey backing kid, fetching from the endpoint on
+// a cache miss.
+func (c *Client) getRawKey(kid string) (JSONWebKey, error) {
+	key, ok := c.keys.get(kid)
+	if !ok {
+		if err := c.refresh(false); err != nil {
+			return JSONWebKey{}, err
+		}
+	}
+
+	key, ok = c.keys.get(kid)
+	if !ok {
+		return JSONWebKey{}, errors.New("unrecognized key id")
+	}
+
+	return key.(JSONWebKey), nil
+}
+
+// refresh refetches the JWKS and updates the cache. Concurrent callers
+// coalesce into a single in-flight HTTP request so a thundering herd of
+// cache misses can't hammer the endpoint. Unless force is set, a refresh
+// that was attempted more recently than minRefreshInterval is skipped and
+// the result of the previous attempt is returned instead.
+func (c *Client) refresh(force bool) error {
+	c.keys.refreshMu.Lock()
+
+	if pending := c.keys.refreshPending; pending != nil {
+		c.keys.refreshMu.Unlock()
+		<-pending
+
+		c.keys.refreshMu.Lock()
+		err := c.keys.lastErr
+		c.keys.refreshMu.Unlock()
 		return err
 	}
 
-	for _, k := range ks {
-		c.keys.put(k.Kid, k)
+	if !force && time.Since(c.keys.lastAttempt) < c.minRefreshInterval {
+		err := c.keys.lastErr
+		c.keys.refreshMu.Unlock()
+		return err
+	}
+
+	pending := make(chan struct{})
+	c.keys.refreshPending = pending
+	c.keys.refreshMu.Unlock()
+
+	ks, ttl, err := fetchKeysWithTTL(c.httpClient, c.endpoint, c.forcePEM)
+
+	c.keys.refreshMu.Lock()
+	c.keys.lastAttempt = time.Now()
+	c.keys.lastErr = err
+	c.keys.refreshPending = nil
+	c.keys.refreshMu.Unlock()
+	close(pending)
+
+	if err != nil {
+		return err
 	}
 
+	c.keys.replace(ks, ttl)
 	return nil
 }
 
 func getPEM(jwk JSONWebKey) ([]byte, error) {
+	if len(jwk.rawPEM) > 0 {
+		return jwk.rawPEM, nil
+	}
 	if len(jwk.X5c) < 1 {
 		return nil, errors.New("No certificate found")
 	}
@@ -115,24 +346,91 @@ func getPEM(jwk JSONWebKey) ([]byte, error) {
 }
 
 func fetchJWKs(origin string) ([]JSONWebKey, error) {
-	var ks JSONWebKeySet
+	ks, _, err := fetchKeysWithTTL(httpClient, origin, false)
+	return ks, err
+}
 
-	resp, err := httpClient.Get(origin)
+// fetchKeysWithTTL fetches origin and returns its keys along with how long
+// they may be cached for, derived from the response's Cache-Control/Expires
+// headers. The response body is parsed as a JWKS unless forcePEM is set or
+// the response looks like one or more PEM blocks (by Content-Type or a
+// leading "-----BEGIN" marker), in which case it is parsed as PEM.
+func fetchKeysWithTTL(hc *http.Client, origin string, forcePEM bool) ([]JSONWebKey, time.Duration, error) {
+	resp, err := hc.Get(origin)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
-	if err := json.NewDecoder(resp.Body).Decode(&ks); err != nil {
-		return nil, err
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ttl := cacheTTLFromHeaders(resp.Header)
+
+	if forcePEM || looksLikePEM(resp.Header.Get("Content-Type"), body) {
+		ks, err := parsePEMKeys(body, resp.Header.Get("Kid"))
+		return ks, ttl, err
+	}
+
+	var ks JSONWebKeySet
+	if err := json.Unmarshal(body, &ks); err != nil {
+		return nil, 0, err
+	}
+
+	return ks.Keys, ttl, nil
+}
+
+func looksLikePEM(contentType string, body []byte) bool {
+	if strings.Contains(contentType, "application/x-pem-file") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("-----BEGIN"))
+}
+
+// cacheTTLFromHeaders derives a cache TTL from Cache-Control: max-age or
+// Expires, falling back to defaultCacheTTL when neither is present. The
+// result is never below minCacheTTL, so a low or zero max-age can't force
+// constant refetching.
+func cacheTTLFromHeaders(h http.Header) time.Duration {
+	if cc := h.Get("Cache-Control"); cc != "" {
+		for _, directive := range strings.Split(cc, ",") {
+			directive = strings.TrimSpace(directive)
+			if secs, ok := strings.CutPrefix(directive, "max-age="); ok {
+				if n, err := strconv.Atoi(secs); err == nil {
+					return clampTTL(time.Duration(n) * time.Second)
+				}
+			}
+		}
+	}
+
+	if exp := h.Get("Expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			return clampTTL(time.Until(t))
+		}
 	}
 
-	return ks.Keys, nil
+	return defaultCacheTTL
+}
+
+func clampTTL(ttl time.Duration) time.Duration {
+	if ttl < minCacheTTL {
+		return minCacheTTL
+	}
+	return ttl
 }
 
 type cache struct {
 	kv  map[string]interface{}
 	mtx *sync.RWMutex
+
+	expiresAt time.Time
+
+	refreshMu      sync.Mutex
+	refreshPending chan struct{}
+	lastAttempt    time.Time
+	lastErr        error
 }
 
 func (c *cache) get(k string) (interface{}, bool) {
@@ -142,8 +440,29 @@ func (c *cache) get(k string) (interface{}, bool) {
 	return v, ok
 }
 
-func (c *cache) put(k string, v interface{}) {
+// all returns a snapshot of every cached JWK.
+func (c *cache) all() []JSONWebKey {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	ks := make([]JSONWebKey, 0, len(c.kv))
+	for _, v := range c.kv {
+		ks = append(ks, v.(JSONWebKey))
+	}
+	return ks
+}
+
+// replace merges ks into the cache and resets the expiry to now+ttl.
+func (c *cache) replace(ks []JSONWebKey, ttl time.Duration) {
 	c.mtx.Lock()
-	c.kv[k] = v
+	for _, k := range ks {
+		c.kv[k.Kid] = k
+	}
+	c.expiresAt = time.Now().Add(ttl)
 	c.mtx.Unlock()
 }
+
+func (c *cache) timeUntilExpiry() time.Duration {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return time.Until(c.expiresAt)
+}
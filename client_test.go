@@ -2,13 +2,16 @@ package jwks
 
 import (
 	"bytes"
+	"crypto/rsa"
 	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const keyId = "go-jwks-test"
@@ -99,25 +102,105 @@ func TestGetKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		t.Fatalf("Expected *rsa.PublicKey, got %T", key)
+	}
+
 	jwks, err := getFixtureAsJWKS()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// keyJSON, err := key.(jose.JSONWebKey).MarshalJSON()
-	// expectedJSON, err := jwks.Keys[0].MarshalJSON()
-	var keyJSON, expectedJSON []byte
-	keyJSON, err = json.Marshal(&key)
+	expected, err := jwks.Keys[0].PublicKey()
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedJSON, err = json.Marshal(&jwks.Keys[0])
+
+	if !rsaKey.Equal(expected.(*rsa.PublicKey)) {
+		t.Fatalf("Expected decoded key to match fixture key")
+	}
+}
+
+func TestNewClientFromIssuer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/jwks", getJwksHandler(t))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"jwks_uri":"`+ts.URL+`/jwks"}`)
+	})
+
+	c, err := NewClientFromIssuer(ts.URL)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if !bytes.Equal(keyJSON, expectedJSON) {
-		t.Fatalf("Expected cached key and fixture key to be the same")
+	if c.Issuer() != ts.URL {
+		t.Fatalf("Expected issuer %v, got %v", ts.URL, c.Issuer())
+	}
+
+	if _, err := c.GetKey(keyId); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheTTLFromHeaders(t *testing.T) {
+	cases := []struct {
+		name string
+		h    http.Header
+		want time.Duration
+	}{
+		{"no headers", http.Header{}, defaultCacheTTL},
+		{"max-age", http.Header{"Cache-Control": []string{"max-age=300"}}, 300 * time.Second},
+		{"max-age below floor", http.Header{"Cache-Control": []string{"max-age=5"}}, minCacheTTL},
+		{"expires", http.Header{"Expires": []string{time.Now().Add(10 * time.Minute).UTC().Format(http.TimeFormat)}}, 10 * time.Minute},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := cacheTTLFromHeaders(tc.h)
+			if tc.name == "expires" {
+				if got < 9*time.Minute || got > 10*time.Minute {
+					t.Fatalf("Expected TTL near %v, got %v", tc.want, got)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Fatalf("Expected TTL %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetKeyUnrecognizedIsRateLimited(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		jwks, err := ioutil.ReadFile("./fixtures/jwks.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, string(jwks))
+	}))
+	defer ts.Close()
+
+	c, err := NewClientWithOptions(ts.URL, ClientOptions{MinRefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&hits, 0)
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetKey("unknown-kid"); err == nil {
+			t.Fatal("Expected unrecognized key id error")
+		}
+	}
+
+	if got := atomic.LoadInt32(&hits); got != 0 {
+		t.Fatalf("Expected no refetch while within MinRefreshInterval, got %v", got)
 	}
 }
 
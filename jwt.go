@@ -0,0 +1,359 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha512" // register SHA-384/512 for crypto.Hash.New
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Claims is the set of claims decoded from a verified JWT payload.
+type Claims map[string]interface{}
+
+// defaultAllowedAlgs is used when no WithAllowedAlgs option is supplied. It
+// deliberately omits "none" and every HMAC ("HS*") algorithm: a JWKS client
+// only ever has public keys, so there is no shared secret to verify an HMAC
+// against, and accepting one would open the door to the classic
+// RSA-key-as-HMAC-secret confusion attack.
+func defaultAllowedAlgs() map[string]bool {
+	return map[string]bool{
+		"RS256": true, "RS384": true, "RS512": true,
+		"PS256": true, "PS384": true, "PS512": true,
+		"ES256": true, "ES384": true, "ES512": true,
+		"EdDSA": true,
+	}
+}
+
+type verifyOptions struct {
+	issuer         string
+	audience       string
+	clockSkew      time.Duration
+	allowedAlgs    map[string]bool
+	requiredClaims []string
+}
+
+// VerifyOption configures a call to Client.Verify or Client.ParseAndVerify.
+type VerifyOption func(*verifyOptions)
+
+// WithIssuer requires the token's "iss" claim to equal iss.
+func WithIssuer(iss string) VerifyOption {
+	return func(o *verifyOptions) { o.issuer = iss }
+}
+
+// WithAudience requires the token's "aud" claim to contain aud.
+func WithAudience(aud string) VerifyOption {
+	return func(o *verifyOptions) { o.audience = aud }
+}
+
+// WithClockSkew allows up to d of clock drift when validating exp/nbf/iat.
+func WithClockSkew(d time.Duration) VerifyOption {
+	return func(o *verifyOptions) { o.clockSkew = d }
+}
+
+// WithAllowedAlgs restricts verification to the given "alg" values, replacing
+// the default allowlist. "none" is always rejected regardless of this option.
+func WithAllowedAlgs(algs ...string) VerifyOption {
+	return func(o *verifyOptions) {
+		allowed := make(map[string]bool, len(algs))
+		for _, alg := range algs {
+			allowed[alg] = true
+		}
+		o.allowedAlgs = allowed
+	}
+}
+
+// WithRequiredClaims fails verification if any of the named claims are
+// absent from the payload.
+func WithRequiredClaims(claims ...string) VerifyOption {
+	return func(o *verifyOptions) { o.requiredClaims = claims }
+}
+
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify parses tokenString as a JWS-compact-serialized JWT, resolves its
+// signing key from the JWKS by "kid" (falling back to trying every cached
+// key, in RFC 7638 thumbprint order, when "kid" is absent), verifies the
+// signature, and validates the standard time-based claims plus whatever opts
+// require. It returns the decoded claims on success.
+func (c *Client) Verify(tokenString string, opts ...VerifyOption) (Claims, error) {
+	o := &verifyOptions{allowedAlgs: defaultAllowedAlgs()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwks: malformed JWT: expected three dot-separated parts")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding JWT header: %w", err)
+	}
+
+	var header jwsHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("jwks: decoding JWT header: %w", err)
+	}
+
+	if header.Alg == "" || strings.EqualFold(header.Alg, "none") {
+		return nil, errors.New(`jwks: alg "none" is not permitted`)
+	}
+	if strings.HasPrefix(header.Alg, "HS") {
+		return nil, fmt.Errorf("jwks: HMAC alg %q is not supported by a public-key JWKS client", header.Alg)
+	}
+	if !o.allowedAlgs[header.Alg] {
+		return nil, fmt.Errorf("jwks: alg %q is not in the allowed list", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding JWT signature: %w", err)
+	}
+
+	candidates, err := c.resolveSigningKeys(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signingInput := []byte(parts[0] + "." + parts[1])
+
+	var verifyErr error
+	verified := false
+	for _, jwk := range candidates {
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			verifyErr = err
+			continue
+		}
+		if err := verifySignature(header.Alg, pub, signingInput, sig); err != nil {
+			verifyErr = err
+			continue
+		}
+		verified = true
+		break
+	}
+	if !verified {
+		if verifyErr == nil {
+			verifyErr = errors.New("jwks: no key matched the token signature")
+		}
+		return nil, verifyErr
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding JWT claims: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, fmt.Errorf("jwks: decoding JWT claims: %w", err)
+	}
+
+	if err := claims.validate(o); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// ParseAndVerify is an alias for Verify.
+func (c *Client) ParseAndVerify(tokenString string, opts ...VerifyOption) (Claims, error) {
+	return c.Verify(tokenString, opts...)
+}
+
+// resolveSigningKeys returns the candidate JWKs a token's signature should be
+// checked against. With a kid, that's the single cached key for it. Without
+// one, every cached key is tried; RFC 7638 thumbprints give each candidate a
+// stable identity to sort by, since map iteration order is not otherwise
+// deterministic.
+func (c *Client) resolveSigningKeys(kid string) ([]JSONWebKey, error) {
+	if kid != "" {
+		jwk, err := c.getRawKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		return []JSONWebKey{jwk}, nil
+	}
+
+	if err := c.refresh(false); err != nil {
+		return nil, err
+	}
+
+	candidates := c.keys.all()
+	if len(candidates) == 0 {
+		return nil, errors.New("unrecognized key id")
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, _ := candidates[i].Thumbprint()
+		tj, _ := candidates[j].Thumbprint()
+		return ti < tj
+	})
+
+	return candidates, nil
+}
+
+func (claims Claims) validate(o *verifyOptions) error {
+	now := time.Now()
+
+	if exp, ok := claims.numericDate("exp"); ok && now.After(exp.Add(o.clockSkew)) {
+		return errors.New("jwks: token is expired")
+	}
+	if nbf, ok := claims.numericDate("nbf"); ok && now.Before(nbf.Add(-o.clockSkew)) {
+		return errors.New("jwks: token is not yet valid")
+	}
+	if iat, ok := claims.numericDate("iat"); ok && iat.After(now.Add(o.clockSkew)) {
+		return errors.New("jwks: token was issued in the future")
+	}
+
+	if o.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != o.issuer {
+			return fmt.Errorf("jwks: unexpected issuer %q", iss)
+		}
+	}
+
+	if o.audience != "" && !claims.hasAudience(o.audience) {
+		return fmt.Errorf("jwks: token audience does not include %q", o.audience)
+	}
+
+	for _, name := range o.requiredClaims {
+		if _, ok := claims[name]; !ok {
+			return fmt.Errorf("jwks: missing required claim %q", name)
+		}
+	}
+
+	return nil
+}
+
+func (claims Claims) numericDate(name string) (time.Time, bool) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+func (claims Claims) hasAudience(aud string) bool {
+	switch v := claims["aud"].(type) {
+	case string:
+		return v == aud
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == aud {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func verifySignature(alg string, pub crypto.PublicKey, signingInput, sig []byte) error {
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		return verifyRSAPKCS1v15(alg, pub, signingInput, sig)
+	case strings.HasPrefix(alg, "PS"):
+		return verifyRSAPSS(alg, pub, signingInput, sig)
+	case strings.HasPrefix(alg, "ES"):
+		return verifyECDSA(alg, pub, signingInput, sig)
+	case alg == "EdDSA":
+		return verifyEdDSA(pub, signingInput, sig)
+	default:
+		return fmt.Errorf("jwks: unsupported signing algorithm %q", alg)
+	}
+}
+
+func algHash(alg string) (crypto.Hash, error) {
+	switch alg[len(alg)-3:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("jwks: unsupported hash size for alg %q", alg)
+	}
+}
+
+func hashSum(h crypto.Hash, data []byte) []byte {
+	hh := h.New()
+	hh.Write(data)
+	return hh.Sum(nil)
+}
+
+func verifyRSAPKCS1v15(alg string, pub crypto.PublicKey, signingInput, sig []byte) error {
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("jwks: alg %q requires an RSA key, got %T", alg, pub)
+	}
+	h, err := algHash(alg)
+	if err != nil {
+		return err
+	}
+	return rsa.VerifyPKCS1v15(rsaPub, h, hashSum(h, signingInput), sig)
+}
+
+func verifyRSAPSS(alg string, pub crypto.PublicKey, signingInput, sig []byte) error {
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("jwks: alg %q requires an RSA key, got %T", alg, pub)
+	}
+	h, err := algHash(alg)
+	if err != nil {
+		return err
+	}
+	opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: h}
+	return rsa.VerifyPSS(rsaPub, h, hashSum(h, signingInput), sig, opts)
+}
+
+func verifyECDSA(alg string, pub crypto.PublicKey, signingInput, sig []byte) error {
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("jwks: alg %q requires an EC key, got %T", alg, pub)
+	}
+	h, err := algHash(alg)
+	if err != nil {
+		return err
+	}
+
+	keySize := (ecPub.Curve.Params().BitSize + 7) / 8
+	if len(sig) != 2*keySize {
+		return errors.New("jwks: invalid ECDSA signature length")
+	}
+
+	r := new(big.Int).SetBytes(sig[:keySize])
+	s := new(big.Int).SetBytes(sig[keySize:])
+
+	if !ecdsa.Verify(ecPub, hashSum(h, signingInput), r, s) {
+		return errors.New("jwks: ECDSA signature verification failed")
+	}
+	return nil
+}
+
+func verifyEdDSA(pub crypto.PublicKey, signingInput, sig []byte) error {
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf(`jwks: alg "EdDSA" requires an Ed25519 key, got %T`, pub)
+	}
+	if !ed25519.Verify(edPub, signingInput, sig) {
+		return errors.New("jwks: EdDSA signature verification failed")
+	}
+	return nil
+}
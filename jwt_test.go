@@ -0,0 +1,143 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newRSATestServer(t *testing.T, kid string) (*httptest.Server, *rsa.PrivateKey) {
+	t.Helper()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := JSONWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(priv.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(priv.PublicKey.E)).Bytes()),
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(JSONWebKeySet{Keys: []JSONWebKey{jwk}})
+	}))
+
+	return ts, priv
+}
+
+func signRS256(t *testing.T, priv *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	h := crypto.SHA256.New()
+	h.Write([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, h.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	ts, priv := newRSATestServer(t, keyId)
+	defer ts.Close()
+
+	c, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	token := signRS256(t, priv, keyId, map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"aud": "my-api",
+		"exp": now.Add(time.Hour).Unix(),
+		"iat": now.Unix(),
+	})
+
+	claims, err := c.Verify(token, WithIssuer("https://issuer.example.com"), WithAudience("my-api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims["iss"] != "https://issuer.example.com" {
+		t.Fatalf("Expected iss claim to round-trip, got %v", claims["iss"])
+	}
+}
+
+func TestVerifyRejectsNoneAlg(t *testing.T) {
+	ts, _ := newRSATestServer(t, keyId)
+	defer ts.Close()
+
+	c, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"` + keyId + `"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"attacker"}`))
+	token := header + "." + payload + "."
+
+	if _, err := c.Verify(token); err == nil {
+		t.Fatal(`Expected alg "none" to be rejected`)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	ts, priv := newRSATestServer(t, keyId)
+	defer ts.Close()
+
+	c, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signRS256(t, priv, keyId, map[string]interface{}{
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+
+	if _, err := c.Verify(token); err == nil {
+		t.Fatal("Expected an expired token to fail verification")
+	}
+}
+
+func TestVerifyRejectsHMACAlg(t *testing.T) {
+	ts, _ := newRSATestServer(t, keyId)
+	defer ts.Close()
+
+	c, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"alg":"HS256","kid":"%s"}`, keyId)))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"attacker"}`))
+	token := header + "." + payload + ".c2ln"
+
+	if _, err := c.Verify(token, WithAllowedAlgs("HS256")); err == nil {
+		t.Fatal("Expected an HMAC-alg token to be rejected even when explicitly allowed")
+	}
+}
@@ -0,0 +1,217 @@
+package jwks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// PublicKey derives a crypto.PublicKey (*rsa.PublicKey, *ecdsa.PublicKey, or
+// ed25519.PublicKey) from the JWK's kty-specific parameters. If those
+// parameters are absent, it falls back to decoding x5c[0] as a DER
+// certificate and returning its embedded public key.
+func (jwk JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		if jwk.N != "" && jwk.E != "" {
+			return jwk.rsaPublicKey()
+		}
+	case "EC":
+		if jwk.Crv != "" && jwk.X != "" && jwk.Y != "" {
+			return jwk.ecPublicKey()
+		}
+	case "OKP":
+		if jwk.Crv == "Ed25519" && jwk.X != "" {
+			return jwk.ed25519PublicKey()
+		}
+	}
+
+	if len(jwk.X5c) > 0 {
+		return x5cPublicKey(jwk.X5c[0])
+	}
+
+	return nil, fmt.Errorf("jwks: unable to derive public key for kty %q", jwk.Kty)
+}
+
+func (jwk JSONWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding RSA modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding RSA exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() {
+		return nil, fmt.Errorf("jwks: RSA exponent too large")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+func (jwk JSONWebKey) ecPublicKey() (*ecdsa.PublicKey, error) {
+	curve, err := ecCurve(jwk.Crv)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding EC x coordinate: %w", err)
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding EC y coordinate: %w", err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func ecCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("jwks: unsupported EC curve %q", crv)
+	}
+}
+
+func (jwk JSONWebKey) ed25519PublicKey() (ed25519.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding Ed25519 public key: %w", err)
+	}
+
+	if len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("jwks: invalid Ed25519 public key length %d", len(x))
+	}
+
+	return ed25519.PublicKey(x), nil
+}
+
+// Thumbprint computes the RFC 7638 JWK thumbprint: the base64url-encoded
+// SHA-256 hash of the JWK's required members, serialized as JSON with its
+// member names sorted lexicographically.
+func (jwk JSONWebKey) Thumbprint() (string, error) {
+	var members map[string]string
+	switch jwk.Kty {
+	case "RSA":
+		members = map[string]string{"e": jwk.E, "kty": jwk.Kty, "n": jwk.N}
+	case "EC":
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X, "y": jwk.Y}
+	case "OKP":
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X}
+	default:
+		return "", fmt.Errorf("jwks: thumbprint not supported for kty %q", jwk.Kty)
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		nameJSON, _ := json.Marshal(name)
+		valueJSON, _ := json.Marshal(members[name])
+		buf.Write(nameJSON)
+		buf.WriteByte(':')
+		buf.Write(valueJSON)
+	}
+	buf.WriteByte('}')
+
+	sum := sha256.Sum256(buf.Bytes())
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// jwkFromPublicKey builds a JSONWebKey carrying pub's kty-specific
+// parameters, the reverse of (JSONWebKey).PublicKey. It's used to bring
+// keys parsed from a raw-PEM endpoint into the same representation as a
+// JWKS-sourced key.
+func jwkFromPublicKey(pub crypto.PublicKey) (JSONWebKey, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		crv, err := jwkCurveName(key.Curve)
+		if err != nil {
+			return JSONWebKey{}, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return JSONWebKey{
+			Kty: "EC",
+			Crv: crv,
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return JSONWebKey{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	default:
+		return JSONWebKey{}, fmt.Errorf("jwks: unsupported public key type %T", pub)
+	}
+}
+
+func jwkCurveName(curve elliptic.Curve) (string, error) {
+	switch curve {
+	case elliptic.P256():
+		return "P-256", nil
+	case elliptic.P384():
+		return "P-384", nil
+	case elliptic.P521():
+		return "P-521", nil
+	default:
+		return "", fmt.Errorf("jwks: unsupported EC curve %q", curve.Params().Name)
+	}
+}
+
+func x5cPublicKey(certB64 string) (crypto.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(certB64)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: decoding x5c certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: parsing x5c certificate: %w", err)
+	}
+
+	return cert.PublicKey, nil
+}
@@ -0,0 +1,131 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+func TestJSONWebKeyPublicKeyRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := JSONWebKey{
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(priv.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(priv.PublicKey.E)).Bytes()),
+	}
+
+	got, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, ok := got.(*rsa.PublicKey)
+	if !ok {
+		t.Fatalf("Expected *rsa.PublicKey, got %T", got)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatalf("Expected decoded RSA key to match source key")
+	}
+}
+
+func TestJSONWebKeyPublicKeyEC(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := JSONWebKey{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(priv.PublicKey.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(priv.PublicKey.Y.Bytes()),
+	}
+
+	got, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, ok := got.(*ecdsa.PublicKey)
+	if !ok {
+		t.Fatalf("Expected *ecdsa.PublicKey, got %T", got)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatalf("Expected decoded EC key to match source key")
+	}
+}
+
+func TestJSONWebKeyPublicKeyOKP(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := JSONWebKey{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(pub),
+	}
+
+	got, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPub, ok := got.(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("Expected ed25519.PublicKey, got %T", got)
+	}
+	if !edPub.Equal(pub) {
+		t.Fatalf("Expected decoded Ed25519 key to match source key")
+	}
+}
+
+func TestJSONWebKeyPublicKeyX5cFallback(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := JSONWebKey{
+		Kty: "RSA",
+		X5c: []string{base64.StdEncoding.EncodeToString(der)},
+	}
+
+	got, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, ok := got.(*rsa.PublicKey)
+	if !ok {
+		t.Fatalf("Expected *rsa.PublicKey, got %T", got)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatalf("Expected x5c fallback key to match source key")
+	}
+}
+
+func TestJSONWebKeyPublicKeyUnsupported(t *testing.T) {
+	jwk := JSONWebKey{Kty: "oct"}
+
+	if _, err := jwk.PublicKey(); err == nil {
+		t.Fatal("Expected an error for an unsupported key type without x5c")
+	}
+}
@@ -0,0 +1,86 @@
+package jwks
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// parsePEMKeys parses a raw-PEM endpoint response into JSONWebKeys. Each
+// block is parsed as an X.509 certificate or a PKIX/PKCS1 public key and
+// cached under the SHA-256 thumbprint of its DER bytes. If the response
+// carries exactly one key and a Kid hint (from a "Kid" response header), the
+// key is also cached under that kid.
+func parsePEMKeys(body []byte, kidHint string) ([]JSONWebKey, error) {
+	var keys []JSONWebKey
+
+	rest := body
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		pub, der, err := publicKeyFromPEMBlock(block)
+		if err != nil {
+			return nil, err
+		}
+
+		jwk, err := jwkFromPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(der)
+		jwk.Kid = hex.EncodeToString(sum[:])
+		jwk.rawPEM = pem.EncodeToMemory(block)
+
+		if block.Type == "CERTIFICATE" {
+			jwk.X5c = []string{base64.StdEncoding.EncodeToString(der)}
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: no PEM blocks found in response")
+	}
+
+	if kidHint != "" && len(keys) == 1 {
+		alias := keys[0]
+		alias.Kid = kidHint
+		keys = append(keys, alias)
+	}
+
+	return keys, nil
+}
+
+func publicKeyFromPEMBlock(block *pem.Block) (interface{}, []byte, error) {
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwks: parsing PEM certificate: %w", err)
+		}
+		return cert.PublicKey, block.Bytes, nil
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwks: parsing PEM public key: %w", err)
+		}
+		return pub, block.Bytes, nil
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwks: parsing PEM RSA public key: %w", err)
+		}
+		return pub, block.Bytes, nil
+	default:
+		return nil, nil, fmt.Errorf("jwks: unsupported PEM block type %q", block.Type)
+	}
+}
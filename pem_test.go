@@ -0,0 +1,122 @@
+package jwks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewPEMClientWithCertificate(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-pem-file")
+		w.Write(certPEM)
+	}))
+	defer ts.Close()
+
+	c, err := NewPEMClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(der)
+	kid := hex.EncodeToString(sum[:])
+
+	key, err := c.GetKey(kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		t.Fatalf("Expected *rsa.PublicKey, got %T", key)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatal("Expected decoded PEM key to match source key")
+	}
+
+	asPEM, err := c.GetKeyAsPEM(kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(asPEM) != string(certPEM) {
+		t.Fatal("Expected GetKeyAsPEM to return the original PEM bytes")
+	}
+}
+
+func TestNewPEMClientKidHeaderAlias(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Kid", "my-custom-kid")
+		w.Write(keyPEM)
+	}))
+	defer ts.Close()
+
+	c, err := NewPEMClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := c.GetKey("my-custom-kid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		t.Fatalf("Expected *rsa.PublicKey, got %T", key)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatal("Expected key resolved via Kid header alias to match source key")
+	}
+}
+
+func TestNewClientSniffsPEMBody(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(keyPEM)
+	}))
+	defer ts.Close()
+
+	if _, err := NewClient(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+}